@@ -0,0 +1,110 @@
+// protocol 包实现隧道控制通道的帧编解码。
+//
+// 范围说明：本包及其在 internal/server.go 中的接入目前只覆盖服务端一侧——
+// 隧道控制连接上原先的换行分隔URL字符串（握手/刷新/启动信令）已替换为本包定义的
+// 二进制定长帧头格式。这是一次不兼容的线上协议变更：仍按旧格式读取换行字符串的客户端
+// 会在收到新握手帧的第一个字节时就解析失败。本仓库当前没有配套的Client实现，
+// 因此尚未提供、也未验证对端解码器；在配套的客户端解码器随同一系列改动落地之前，
+// 不要假定这是一次可与旧客户端互通的变更。
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// Version 当前协议版本，握手阶段双方必须一致
+const Version uint8 = 1
+
+// magic 帧起始魔数，用于在流中定位帧边界
+var magic = [2]byte{'N', 'P'}
+
+// maxFrameLen 单帧负载的最大长度，避免恶意或异常长度拖垮读取方
+const maxFrameLen = 4 << 10 // 4 KiB
+
+// FrameType 标识帧承载的信令类型
+type FrameType uint8
+
+const (
+	FrameHandshake FrameType = iota // 握手信令，替代原 Fragment 为 tlsCode 的隧道URL
+	FrameFlush                      // 刷新连接池信令，替代原 Fragment "0"
+	FrameLaunchTCP                  // TCP启动信令，替代原 Fragment "1"
+	FrameLaunchUDP                  // UDP启动信令，替代原 Fragment "2"
+	FrameHeartbeat                  // 心跳信令，替代原裸换行心跳
+)
+
+// ErrBadMagic 帧魔数校验失败
+var ErrBadMagic = errors.New("protocol: bad magic")
+
+// ErrFrameTooLarge 帧负载长度超过 maxFrameLen
+var ErrFrameTooLarge = errors.New("protocol: frame too large")
+
+// ErrVersionMismatch 对端协议版本与本端不一致
+var ErrVersionMismatch = errors.New("protocol: version mismatch")
+
+// Frame 隧道控制通道上传输的一个信令单元
+type Frame struct {
+	Type    FrameType // 信令类型
+	Seq     uint32    // 序列号，便于排查乱序与丢包
+	Payload []byte    // 负载，通常是启动信号里携带的连接 id
+}
+
+// WriteFrame 将 frame 序列化为 [magic:2][type:1][len:2][seq:4][payload] 并写入 w，
+// 其中 type 字节的高4位为 Version、低4位为帧类型
+func WriteFrame(w io.Writer, frame Frame) error {
+	if len(frame.Payload) > maxFrameLen {
+		return ErrFrameTooLarge
+	}
+
+	header := make([]byte, 2+1+2+4)
+	copy(header[0:2], magic[:])
+	header[2] = byte(Version<<4) | byte(frame.Type&0x0f)
+	binary.BigEndian.PutUint16(header[3:5], uint16(len(frame.Payload)))
+	binary.BigEndian.PutUint32(header[5:9], frame.Seq)
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if len(frame.Payload) == 0 {
+		return nil
+	}
+	_, err := w.Write(frame.Payload)
+	return err
+}
+
+// ReadFrame 从 r 中读取并校验一个完整帧
+func ReadFrame(r io.Reader) (Frame, error) {
+	header := make([]byte, 2+1+2+4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return Frame{}, err
+	}
+
+	if header[0] != magic[0] || header[1] != magic[1] {
+		return Frame{}, ErrBadMagic
+	}
+
+	version := header[2] >> 4
+	if version != Version {
+		return Frame{}, ErrVersionMismatch
+	}
+
+	frameLen := binary.BigEndian.Uint16(header[3:5])
+	if frameLen > maxFrameLen {
+		return Frame{}, ErrFrameTooLarge
+	}
+
+	frame := Frame{
+		Type: FrameType(header[2] & 0x0f),
+		Seq:  binary.BigEndian.Uint32(header[5:9]),
+	}
+	if frameLen == 0 {
+		return frame, nil
+	}
+
+	frame.Payload = make([]byte, frameLen)
+	if _, err := io.ReadFull(r, frame.Payload); err != nil {
+		return Frame{}, err
+	}
+	return frame, nil
+}
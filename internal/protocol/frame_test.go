@@ -0,0 +1,67 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// TestWriteReadFrameRoundTrip 校验带负载与空负载的帧都能写入后原样读回
+func TestWriteReadFrameRoundTrip(t *testing.T) {
+	cases := []Frame{
+		{Type: FrameHandshake, Seq: 1, Payload: []byte("0")},
+		{Type: FrameLaunchTCP, Seq: 42, Payload: []byte("conn-id-123")},
+		{Type: FrameHeartbeat, Seq: 0, Payload: nil},
+	}
+
+	for _, frame := range cases {
+		var buf bytes.Buffer
+		if err := WriteFrame(&buf, frame); err != nil {
+			t.Fatalf("WriteFrame(%+v) error: %v", frame, err)
+		}
+
+		got, err := ReadFrame(&buf)
+		if err != nil {
+			t.Fatalf("ReadFrame after WriteFrame(%+v) error: %v", frame, err)
+		}
+
+		if got.Type != frame.Type || got.Seq != frame.Seq || !bytes.Equal(got.Payload, frame.Payload) {
+			t.Fatalf("round trip mismatch: want %+v, got %+v", frame, got)
+		}
+	}
+}
+
+// TestWriteFramePayloadTooLarge 负载超过 maxFrameLen 时应拒绝写入
+func TestWriteFramePayloadTooLarge(t *testing.T) {
+	frame := Frame{Type: FrameLaunchUDP, Payload: make([]byte, maxFrameLen+1)}
+
+	var buf bytes.Buffer
+	if err := WriteFrame(&buf, frame); !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("WriteFrame with oversized payload: want ErrFrameTooLarge, got %v", err)
+	}
+}
+
+// TestReadFrameBadMagic 帧起始魔数不匹配时应返回 ErrBadMagic
+func TestReadFrameBadMagic(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{'X', 'X', 0, 0, 0, 0, 0, 0, 0})
+
+	if _, err := ReadFrame(buf); !errors.Is(err, ErrBadMagic) {
+		t.Fatalf("ReadFrame with bad magic: want ErrBadMagic, got %v", err)
+	}
+}
+
+// TestReadFrameVersionMismatch 协议版本与本端不一致时应返回 ErrVersionMismatch
+func TestReadFrameVersionMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	frame := Frame{Type: FrameFlush, Seq: 7}
+	if err := WriteFrame(&buf, frame); err != nil {
+		t.Fatalf("WriteFrame error: %v", err)
+	}
+
+	header := buf.Bytes()
+	header[2] = byte((Version+1)<<4) | byte(frame.Type&0x0f)
+
+	if _, err := ReadFrame(bytes.NewReader(header)); !errors.Is(err, ErrVersionMismatch) {
+		t.Fatalf("ReadFrame with mismatched version: want ErrVersionMismatch, got %v", err)
+	}
+}
@@ -10,32 +10,204 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/yosebyte/x/conn"
 	"github.com/yosebyte/x/log"
+
+	"github.com/yosebyte/nodepass/internal/protocol"
+)
+
+const (
+	udpSessionIdleTimeout = 60 * time.Second      // UDP会话空闲超时时间
+	udpSessionSweepPeriod = 10 * time.Second      // UDP会话清理周期
+	udpSessionSendBuf     = 128                   // UDP会话发送通道缓冲区大小
+	controlChanBuf        = 256                   // 控制帧写入队列的缓冲区大小，吸收心跳与启动信号的突发
+	acceptErrorBackoff    = 50 * time.Millisecond // Accept连续出错时的重试间隔，避免监听器被关闭后busy-spin
 )
 
+// udpSession 维护一条UDP"流"在隧道上的状态
+type udpSession struct {
+	id         string       // 隧道连接池分配的连接id
+	remoteConn net.Conn     // 绑定的隧道连接
+	clientAddr *net.UDPAddr // 发起该流量的客户端地址
+	send       chan []byte  // 待转发到隧道连接的数据报
+
+	mu         sync.Mutex // 保护lastActive与closed，避免send被关闭的同时仍有goroutine向其写入
+	lastActive time.Time  // 最近一次收发时间，供空闲回收判断
+	closed     bool       // send是否已被关闭；Stop/janitor/closeUDPSession三方都可能触发关闭
+}
+
+// trySend 在持锁状态下检查会话是否已关闭再投递，避免与并发的关闭操作竞争同一个channel；
+// 会话繁忙（缓冲已满）时丢弃该数据报，而不是阻塞整条UDP转发循环
+func (sess *udpSession) trySend(payload []byte) bool {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if sess.closed {
+		return false
+	}
+
+	select {
+	case sess.send <- payload:
+		sess.lastActive = time.Now()
+		return true
+	default:
+		return false
+	}
+}
+
+// closeSend 关闭send通道，并保证整个生命周期内只关闭一次，
+// 供Stop/udpSessionJanitor/closeUDPSession三个owner共用
+func (sess *udpSession) closeSend() {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if sess.closed {
+		return
+	}
+	sess.closed = true
+	close(sess.send)
+}
+
+// touch 刷新会话的最近活跃时间
+func (sess *udpSession) touch() {
+	sess.mu.Lock()
+	sess.lastActive = time.Now()
+	sess.mu.Unlock()
+}
+
+// idleFor 返回会话距离上次活跃经过的时长
+func (sess *udpSession) idleFor() time.Duration {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	return time.Since(sess.lastActive)
+}
+
+// controlFrame 是一次待写入隧道控制通道的信令，controlWriter串行处理，
+// done用于让发起方感知写入结果而不必相互等待
+type controlFrame struct {
+	frame protocol.Frame
+	done  chan error
+}
+
 // Server 实现服务器模式功能
 type Server struct {
-	Common                          // 继承通用功能
-	serverMU       sync.Mutex       // 服务器互斥锁
-	tunnelListener net.Listener     // 隧道监听器
-	targetListener *net.TCPListener // 目标监听器
-	tlsConfig      *tls.Config      // TLS配置
-	semaphore      chan struct{}    // 信号量通道
+	Common                                // 继承通用功能
+	tunnelConn     net.Conn               // 隧道控制连接，承载方式不同类型也不同（TCP/TLS/QUIC流/WebSocket），帧读写一律走这个字段
+	tunnelListener net.Listener           // 隧道监听器
+	targetListener *net.TCPListener       // 目标监听器
+	tlsConfig      *tls.Config            // TLS配置
+	transport      TunnelTransport        // 隧道承载方式，由URL scheme选择
+	socketOptions  SocketOptions          // 隧道/目标TCP连接的内核层调优参数
+	semaphore      chan struct{}          // 信号量通道
+	frameSeq       uint32                 // 帧序列号，原子递增
+	udpSessionsMU  sync.Mutex             // UDP会话表互斥锁
+	udpSessions    map[string]*udpSession // UDP会话表，键为客户端地址
+	controlCh      chan controlFrame      // 控制帧写入队列，由controlWriter独占消费
+
+	inShutdown      int32                   // 是否处于优雅关闭阶段，healthCheck据此停止心跳/刷新
+	activeExchanges sync.WaitGroup          // 在途的TCP数据交换goroutine计数，供Shutdown等待排空
+	onShutdownMU    sync.Mutex              // 保护onShutdownHooks
+	onShutdownHooks []func(context.Context) // 关闭回调，按注册顺序的逆序（LIFO）执行
+}
+
+// nextSeq 返回下一个帧序列号
+func (s *Server) nextSeq() uint32 {
+	return atomic.AddUint32(&s.frameSeq, 1)
+}
+
+// writeControlFrame 将frame交给controlWriter串行写入隧道连接，
+// 替代此前每次写信令都要争抢的serverMU
+func (s *Server) writeControlFrame(frame protocol.Frame) error {
+	done := make(chan error, 1)
+	select {
+	case s.controlCh <- controlFrame{frame: frame, done: done}:
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+}
+
+// controlWriter 独占tunnelConn的写入权限，串行消费controlCh中的信令，
+// 使心跳、TCP/UDP启动信号互不阻塞彼此的生产者
+func (s *Server) controlWriter() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case cf := <-s.controlCh:
+			cf.done <- protocol.WriteFrame(s.tunnelConn, cf.frame)
+		}
+	}
+}
+
+// RegisterOnShutdown 注册一个在Shutdown排空完成后调用的回调，
+// 供嵌入方（如未来的REST/gRPC管理端点）参与关闭生命周期
+func (s *Server) RegisterOnShutdown(hook func(context.Context)) {
+	s.onShutdownMU.Lock()
+	s.onShutdownHooks = append(s.onShutdownHooks, hook)
+	s.onShutdownMU.Unlock()
+}
+
+// Shutdown 优雅关闭：先停止接受新的目标连接，
+// 等待在途的数据交换完成（或ctx超时），再关闭隧道连接池与监听器，
+// 最后按LIFO顺序执行已注册的关闭回调
+func (s *Server) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&s.inShutdown, 1)
+
+	// 优先关闭目标监听器，停止接受新的业务连接，但不影响在途交换
+	if s.targetListener != nil {
+		s.targetListener.Close()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		s.activeExchanges.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		s.logger.Debug("Shutdown drain timed out: %v", ctx.Err())
+	}
+
+	s.Stop()
+
+	s.onShutdownMU.Lock()
+	hooks := append([]func(context.Context){}, s.onShutdownHooks...)
+	s.onShutdownMU.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i](ctx)
+	}
+
+	return ctx.Err()
 }
 
 // NewServer 创建新的服务器实例
 func NewServer(parsedURL *url.URL, tlsCode string, tlsConfig *tls.Config, logger *log.Logger) *Server {
+	socketOptions := parseSocketOptions(parsedURL.Query())
 	server := &Server{
 		Common: Common{
 			tlsCode: tlsCode,
 			logger:  logger,
 		},
-		tlsConfig: tlsConfig,
-		semaphore: make(chan struct{}, semaphoreLimit),
+		tlsConfig:     tlsConfig,
+		transport:     selectTransport(parsedURL.Scheme, tlsConfig, socketOptions),
+		socketOptions: socketOptions,
+		semaphore:     make(chan struct{}, semaphoreLimit),
+		udpSessions:   make(map[string]*udpSession),
+		controlCh:     make(chan controlFrame, controlChanBuf),
 	}
 	server.getAddress(parsedURL)
 	return server
@@ -62,10 +234,10 @@ func (s *Server) Manage() {
 	<-ctx.Done()
 	stop()
 
-	// 执行关闭过程
+	// 执行关闭过程，优先走优雅排空路径，必要时由shutdownTimeout兜底
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
-	if err := s.shutdown(shutdownCtx, s.Stop); err != nil {
+	if err := s.shutdown(shutdownCtx, func() { s.Shutdown(shutdownCtx) }); err != nil {
 		s.logger.Error("Server shutdown error: %v", err)
 	} else {
 		s.logger.Info("Server shutdown complete")
@@ -76,6 +248,16 @@ func (s *Server) Manage() {
 func (s *Server) Start() error {
 	s.initContext()
 
+	// 重建controlCh与udpSessions：Manage()的重启循环会反复调用Start，
+	// 若沿用上一轮遗留的controlCh，上一轮controlWriter在ctx.Done()时尚未消费的
+	// 过期信令（例如引用了已失效连接池id的启动帧）会被本轮新起的controlWriter
+	// 当作第一条信令写到刚握手好的隧道连接上；udpSessions中遗留的会话也已绑定
+	// 到上一轮失效的隧道连接，一并丢弃重新开始
+	s.controlCh = make(chan controlFrame, controlChanBuf)
+	s.udpSessionsMU.Lock()
+	s.udpSessions = make(map[string]*udpSession)
+	s.udpSessionsMU.Unlock()
+
 	// 初始化监听器
 	if err := s.initListener(); err != nil {
 		return err
@@ -86,12 +268,18 @@ func (s *Server) Start() error {
 		return err
 	}
 
-	// 初始化隧道连接池
-	s.tunnelPool = conn.NewServerPool(s.tlsConfig, s.tunnelListener)
+	// 初始化隧道连接池；承载方式若已自行完成TLS（tls/quic/ws均在Listen阶段终结），
+	// 连接池不能再对Accept出的连接做一次TLS握手，否则每条数据连接都会因双重握手而失败
+	poolTLSConfig := s.tlsConfig
+	if s.transport.TerminatesTLS() {
+		poolTLSConfig = nil
+	}
+	s.tunnelPool = conn.NewServerPool(poolTLSConfig, s.tunnelListener)
 
 	go s.tunnelPool.ServerManager()
 	go s.serverLaunch()
 	go s.statsReporter()
+	go s.controlWriter()
 
 	return s.healthCheck()
 }
@@ -110,6 +298,15 @@ func (s *Server) Stop() {
 		s.logger.Debug("Tunnel connection closed: active %v", active)
 	}
 
+	// 关闭所有UDP会话
+	s.udpSessionsMU.Lock()
+	for key, sess := range s.udpSessions {
+		sess.closeSend()
+		sess.remoteConn.Close()
+		delete(s.udpSessions, key)
+	}
+	s.udpSessionsMU.Unlock()
+
 	// 关闭UDP连接
 	if s.targetUDPConn != nil {
 		s.targetUDPConn.Close()
@@ -123,9 +320,9 @@ func (s *Server) Stop() {
 	}
 
 	// 关闭隧道连接
-	if s.tunnelTCPConn != nil {
-		s.tunnelTCPConn.Close()
-		s.logger.Debug("Tunnel connection closed: %v", s.tunnelTCPConn.LocalAddr())
+	if s.tunnelConn != nil {
+		s.tunnelConn.Close()
+		s.logger.Debug("Tunnel connection closed: %v", s.tunnelConn.LocalAddr())
 	}
 
 	// 关闭目标监听器
@@ -143,19 +340,19 @@ func (s *Server) Stop() {
 
 // 初始化监听器
 func (s *Server) initListener() error {
-	// 初始化隧道监听器
-	tunnelListener, err := net.ListenTCP("tcp", s.tunnelAddr)
+	// 按选定的承载方式（tcp/tls/quic/ws/wss）初始化隧道监听器
+	tunnelListener, err := s.transport.Listen(s.ctx, s.tunnelAddr.String())
 	if err != nil {
 		return err
 	}
 	s.tunnelListener = tunnelListener
 
-	// 初始化目标TCP监听器
-	targetListener, err := net.ListenTCP("tcp", s.targetTCPAddr)
+	// 初始化目标TCP监听器，应用SocketOptions中的监听阶段调优参数
+	rawTargetListener, err := listenConfig(s.socketOptions).Listen(s.ctx, "tcp", s.targetTCPAddr.String())
 	if err != nil {
 		return err
 	}
-	s.targetListener = targetListener
+	s.targetListener = rawTargetListener.(*net.TCPListener)
 
 	// 初始化目标UDP监听器
 	targetUDPConn, err := net.ListenUDP("udp", s.targetUDPAddr)
@@ -168,25 +365,40 @@ func (s *Server) initListener() error {
 }
 
 // 与客户端进行握手
+// tunnelHandshake 接受隧道连接并发送握手帧。
+//
+// 注意：握手及后续控制信令已改用 internal/protocol 的二进制定长帧格式，
+// 不再是旧版换行分隔的URL字符串；这是服务端一侧的协议变更，仓库内目前没有
+// 配套的Client实现与之对应，升级前已有客户端需要随同更新，否则会在握手阶段就解析失败
 func (s *Server) tunnelHandshake() error {
-	// 接受隧道连接
-	tunnelTCPConn, err := s.tunnelListener.Accept()
+	// 接受隧道连接，承载方式不同时返回的连接类型也不同（TCP/TLS/QUIC流/WebSocket）
+	tunnelConn, err := s.tunnelListener.Accept()
 	if err != nil {
 		return err
 	}
-	s.tunnelTCPConn = tunnelTCPConn.(*net.TCPConn)
+	s.tunnelConn = tunnelConn
+	// Common.tunnelTCPConn 是 *net.TCPConn 具体类型，只有承载方式底层确实是TCP时才能赋值；
+	// 帧读写统一经由上面的 s.tunnelConn，不依赖这个字段
+	if tcpConn, ok := underlyingTCPConn(tunnelConn); ok {
+		if err := applySocketOptions(tcpConn, s.socketOptions); err != nil {
+			s.logger.Debug("Socket options failed: %v", err)
+		}
+		s.tunnelTCPConn = tcpConn
+	}
 
-	// 构建并发送隧道URL到客户端
-	tunnelURL := &url.URL{
-		Fragment: s.tlsCode,
+	// 构建并发送握手帧到客户端，负载沿用原 tlsCode 约定；
+	// 帧头中的协议版本号使协议版本不一致的新旧客户端能在握手阶段快速失败
+	handshakeFrame := protocol.Frame{
+		Type:    protocol.FrameHandshake,
+		Seq:     s.nextSeq(),
+		Payload: []byte(s.tlsCode),
 	}
-	_, err = s.tunnelTCPConn.Write([]byte(tunnelURL.String() + "\n"))
-	if err != nil {
+	if err := protocol.WriteFrame(s.tunnelConn, handshakeFrame); err != nil {
 		return err
 	}
 
-	s.logger.Debug("Tunnel signal -> : %v -> %v", tunnelURL.String(), s.tunnelTCPConn.RemoteAddr())
-	s.logger.Debug("Tunnel handshaked: %v <-> %v", s.tunnelTCPConn.LocalAddr(), s.tunnelTCPConn.RemoteAddr())
+	s.logger.Debug("Tunnel signal -> : %v (%v) -> %v", s.tlsCode, s.transport.Name(), s.tunnelConn.RemoteAddr())
+	s.logger.Debug("Tunnel handshaked: %v <-> %v", s.tunnelConn.LocalAddr(), s.tunnelConn.RemoteAddr())
 	return nil
 }
 
@@ -197,6 +409,7 @@ func (s *Server) serverLaunch() {
 		if s.tunnelPool.Ready() {
 			go s.serverTCPLoop()
 			go s.serverUDPLoop()
+			go s.udpSessionJanitor()
 			return
 		}
 		time.Sleep(time.Millisecond)
@@ -211,19 +424,19 @@ func (s *Server) healthCheck() error {
 		case <-s.ctx.Done():
 			return s.ctx.Err()
 		default:
-			// 发送心跳包
-			if !s.serverMU.TryLock() {
-				continue
+			// 进入优雅关闭阶段后不再发送心跳/刷新信号
+			if atomic.LoadInt32(&s.inShutdown) != 0 {
+				return nil
 			}
+
 			// 定期刷新连接池
 			if time.Since(lastFlushed) >= ReloadInterval {
-				flushURL := &url.URL{
-					Fragment: "0", // 刷新模式
+				flushFrame := protocol.Frame{
+					Type: protocol.FrameFlush,
+					Seq:  s.nextSeq(),
 				}
 
-				_, err := s.tunnelTCPConn.Write([]byte(flushURL.String() + "\n"))
-				if err != nil {
-					s.serverMU.Unlock()
+				if err := s.writeControlFrame(flushFrame); err != nil {
 					return err
 				}
 
@@ -232,14 +445,16 @@ func (s *Server) healthCheck() error {
 				time.Sleep(reportInterval) // 等待连接池刷新完成
 				s.logger.Debug("Tunnel pool reset: %v active connections", s.tunnelPool.Active())
 			} else {
-				// 定期发送心跳包
-				_, err := s.tunnelTCPConn.Write([]byte("\n"))
-				if err != nil {
-					s.serverMU.Unlock()
+				// 定期发送心跳帧
+				heartbeatFrame := protocol.Frame{
+					Type: protocol.FrameHeartbeat,
+					Seq:  s.nextSeq(),
+				}
+
+				if err := s.writeControlFrame(heartbeatFrame); err != nil {
 					return err
 				}
 			}
-			s.serverMU.Unlock()
 			time.Sleep(reportInterval)
 		}
 	}
@@ -255,6 +470,13 @@ func (s *Server) serverTCPLoop() {
 			// 接受来自目标的TCP连接
 			targetConn, err := s.targetListener.Accept()
 			if err != nil {
+				// Shutdown会在排空等待期间提前关闭targetListener，此时Accept会持续立即出错；
+				// 在ctx.Done()上等一小段时间再重试，避免在这段窗口内busy-spin占满一个CPU核心
+				select {
+				case <-s.ctx.Done():
+					return
+				case <-time.After(acceptErrorBackoff):
+				}
 				continue
 			}
 
@@ -265,12 +487,17 @@ func (s *Server) serverTCPLoop() {
 			}()
 
 			s.targetTCPConn = targetConn.(*net.TCPConn)
+			if err := applySocketOptions(s.targetTCPConn, s.socketOptions); err != nil {
+				s.logger.Debug("Socket options failed: %v", err)
+			}
 			s.logger.Debug("Target connection: %v <-> %v", targetConn.LocalAddr(), targetConn.RemoteAddr())
 
 			// 使用信号量限制并发数
 			s.semaphore <- struct{}{}
 
+			s.activeExchanges.Add(1)
 			go func(targetConn net.Conn) {
+				defer s.activeExchanges.Done()
 				defer func() { <-s.semaphore }()
 
 				// 从连接池获取连接
@@ -290,22 +517,19 @@ func (s *Server) serverTCPLoop() {
 
 				s.logger.Debug("Tunnel connection: %v <-> %v", remoteConn.LocalAddr(), remoteConn.RemoteAddr())
 
-				// 构建并发送启动URL到客户端
-				launchURL := &url.URL{
-					Host:     id,
-					Fragment: "1", // TCP模式
+				// 构建并发送TCP启动帧到客户端
+				launchFrame := protocol.Frame{
+					Type:    protocol.FrameLaunchTCP,
+					Seq:     s.nextSeq(),
+					Payload: []byte(id),
 				}
 
-				s.serverMU.Lock()
-				_, err = s.tunnelTCPConn.Write([]byte(launchURL.String() + "\n"))
-				s.serverMU.Unlock()
-
-				if err != nil {
+				if err := s.writeControlFrame(launchFrame); err != nil {
 					s.logger.Error("Write failed: %v", err)
 					return
 				}
 
-				s.logger.Debug("TCP launch signal: %v -> %v", id, s.tunnelTCPConn.RemoteAddr())
+				s.logger.Debug("TCP launch signal: %v -> %v", id, s.tunnelConn.RemoteAddr())
 				s.logger.Debug("Starting exchange: %v <-> %v", remoteConn.LocalAddr(), targetConn.LocalAddr())
 
 				// 交换数据
@@ -322,7 +546,7 @@ func (s *Server) serverTCPLoop() {
 	}
 }
 
-// UDP请求处理循环
+// UDP请求处理循环，按客户端地址分流到独立的会话
 func (s *Server) serverUDPLoop() {
 	for {
 		select {
@@ -339,71 +563,152 @@ func (s *Server) serverUDPLoop() {
 			s.AddUDPReceived(uint64(n))
 			s.logger.Debug("Target connection: %v <-> %v", s.targetUDPConn.LocalAddr(), clientAddr)
 
-			// 从连接池获取连接
-			id, remoteConn := s.tunnelPool.ServerGet()
-			if remoteConn == nil {
+			sess := s.getOrCreateUDPSession(clientAddr)
+			if sess == nil {
 				continue
 			}
 
-			s.logger.Debug("Tunnel connection: %v <- active %v", id, s.tunnelPool.Active())
+			payload := make([]byte, n)
+			copy(payload, buffer[:n])
 
-			defer func() {
-				if remoteConn != nil {
-					remoteConn.Close()
-				}
-			}()
+			if !sess.trySend(payload) {
+				s.logger.Debug("UDP session backlogged or closed, dropping datagram: %v", clientAddr)
+			}
+		}
+	}
+}
 
-			s.logger.Debug("Tunnel connection: %v <-> %v", remoteConn.LocalAddr(), remoteConn.RemoteAddr())
+// getOrCreateUDPSession 按客户端地址查找或新建UDP会话
+func (s *Server) getOrCreateUDPSession(clientAddr *net.UDPAddr) *udpSession {
+	key := clientAddr.String()
 
-			// 使用信号量限制并发数
-			s.semaphore <- struct{}{}
+	s.udpSessionsMU.Lock()
+	if sess, ok := s.udpSessions[key]; ok {
+		s.udpSessionsMU.Unlock()
+		return sess
+	}
+	s.udpSessionsMU.Unlock()
 
-			go func(buffer []byte, n int, clientAddr *net.UDPAddr, remoteConn net.Conn) {
-				defer func() { <-s.semaphore }()
+	// 从连接池获取一条专属该会话的隧道连接
+	id, remoteConn := s.tunnelPool.ServerGet()
+	if remoteConn == nil {
+		s.logger.Error("Get failed: %v", id)
+		return nil
+	}
 
-				// 构建并发送启动URL到客户端
-				launchURL := &url.URL{
-					Host:     id,
-					Fragment: "2", // UDP模式
-				}
+	s.logger.Debug("Tunnel connection: %v <- active %v", id, s.tunnelPool.Active())
+	s.logger.Debug("Tunnel connection: %v <-> %v", remoteConn.LocalAddr(), remoteConn.RemoteAddr())
 
-				s.serverMU.Lock()
-				_, err = s.tunnelTCPConn.Write([]byte(launchURL.String() + "\n"))
-				s.serverMU.Unlock()
+	// 构建并发送UDP启动帧到客户端，仅在会话创建时发送一次
+	launchFrame := protocol.Frame{
+		Type:    protocol.FrameLaunchUDP,
+		Seq:     s.nextSeq(),
+		Payload: []byte(id),
+	}
 
-				if err != nil {
-					s.logger.Error("Write failed: %v", err)
-					return
-				}
+	if err := s.writeControlFrame(launchFrame); err != nil {
+		s.logger.Error("Write failed: %v", err)
+		remoteConn.Close()
+		return nil
+	}
 
-				s.logger.Debug("UDP launch signal: %v -> %v", id, s.tunnelTCPConn.RemoteAddr())
-				s.logger.Debug("Starting transfer: %v <-> %v", remoteConn.LocalAddr(), s.targetUDPConn.LocalAddr())
+	s.logger.Debug("UDP launch signal: %v -> %v", id, s.tunnelConn.RemoteAddr())
 
-				// 发送数据到远程连接
-				_, err = remoteConn.Write(buffer[:n])
-				if err != nil {
-					s.logger.Error("Write failed: %v", err)
-					return
-				}
+	sess := &udpSession{
+		id:         id,
+		remoteConn: remoteConn,
+		clientAddr: clientAddr,
+		send:       make(chan []byte, udpSessionSendBuf),
+	}
+	sess.touch()
 
-				// 读取远程连接的响应
-				n, err = remoteConn.Read(buffer)
-				if err != nil {
-					s.logger.Error("Read failed: %v", err)
-					return
-				}
+	s.udpSessionsMU.Lock()
+	s.udpSessions[key] = sess
+	s.udpSessionsMU.Unlock()
 
-				// 将响应发送回客户端
-				_, err = s.targetUDPConn.WriteToUDP(buffer[:n], clientAddr)
-				if err != nil {
-					s.logger.Error("Write failed: %v", err)
-					return
+	go s.udpSessionWriter(sess)
+	go s.udpSessionReader(sess)
+
+	return sess
+}
+
+// udpSessionWriter 将会话待发数据写入隧道连接，直到会话被回收
+func (s *Server) udpSessionWriter(sess *udpSession) {
+	for payload := range sess.send {
+		if _, err := sess.remoteConn.Write(payload); err != nil {
+			s.logger.Error("Write failed: %v", err)
+			return
+		}
+		s.AddUDPSent(uint64(len(payload)))
+	}
+}
+
+// udpSessionReader 持续读取隧道连接的回包并转发给对应客户端，直至空闲超时或连接关闭
+func (s *Server) udpSessionReader(sess *udpSession) {
+	buffer := make([]byte, udpDataBufSize)
+	for {
+		n, err := sess.remoteConn.Read(buffer)
+		if err != nil {
+			s.logger.Debug("UDP session closed: %v (%v)", sess.clientAddr, err)
+			s.closeUDPSession(sess)
+			return
+		}
+
+		sess.touch()
+
+		if _, err := s.targetUDPConn.WriteToUDP(buffer[:n], sess.clientAddr); err != nil {
+			s.logger.Error("Write failed: %v", err)
+			s.closeUDPSession(sess)
+			return
+		}
+
+		bytesReceived, bytesSent := s.GetUDPStats()
+		s.logger.Debug("Transfer complete: %v bytes transferred", bytesReceived+bytesSent)
+	}
+}
+
+// udpSessionJanitor 周期性清理空闲超时的UDP会话
+func (s *Server) udpSessionJanitor() {
+	ticker := time.NewTicker(udpSessionSweepPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.udpSessionsMU.Lock()
+			expired := make([]*udpSession, 0)
+			for key, sess := range s.udpSessions {
+				if sess.idleFor() >= udpSessionIdleTimeout {
+					expired = append(expired, sess)
+					delete(s.udpSessions, key)
 				}
+			}
+			s.udpSessionsMU.Unlock()
 
-				s.AddUDPSent(uint64(n))
-				bytesReceived, bytesSent := s.GetUDPStats()
-				s.logger.Debug("Transfer complete: %v bytes transferred", bytesReceived+bytesSent)
-			}(buffer, n, clientAddr, remoteConn)
+			for _, sess := range expired {
+				s.logger.Debug("UDP session expired: %v", sess.clientAddr)
+				sess.closeSend()
+				sess.remoteConn.Close()
+			}
 		}
 	}
 }
+
+// closeUDPSession 从会话表移除并关闭指定会话
+func (s *Server) closeUDPSession(sess *udpSession) {
+	key := sess.clientAddr.String()
+
+	s.udpSessionsMU.Lock()
+	if current, ok := s.udpSessions[key]; ok && current == sess {
+		delete(s.udpSessions, key)
+	} else {
+		s.udpSessionsMU.Unlock()
+		return
+	}
+	s.udpSessionsMU.Unlock()
+
+	sess.closeSend()
+	sess.remoteConn.Close()
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SocketOptions 描述作用于隧道/目标TCP连接的内核层调优参数
+type SocketOptions struct {
+	KeepAlive       bool          // 是否开启TCP KeepAlive
+	KeepAlivePeriod time.Duration // KeepAlive探测间隔
+	KeepAliveCount  int           // KeepAlive最大探测失败次数（仅Linux生效）
+	ReadBuffer      int           // SO_RCVBUF，0表示使用系统默认值
+	WriteBuffer     int           // SO_SNDBUF，0表示使用系统默认值
+	NoDelay         bool          // 是否禁用Nagle算法
+	UserTimeout     time.Duration // TCP_USER_TIMEOUT，未确认数据的最长存活时间（仅Linux生效）
+}
+
+// defaultSocketOptions 默认开启30秒周期的KeepAlive，
+// 使僵死的对端能在几分钟内被探测到，而不是像此前那样可能要等上数小时
+var defaultSocketOptions = SocketOptions{
+	KeepAlive:       true,
+	KeepAlivePeriod: 30 * time.Second,
+	KeepAliveCount:  3,
+	NoDelay:         true,
+}
+
+// parseSocketOptions 从URL查询参数中解析SocketOptions，缺省或非法值回退到defaultSocketOptions
+func parseSocketOptions(query url.Values) SocketOptions {
+	opts := defaultSocketOptions
+
+	if v, err := strconv.ParseBool(query.Get("keepalive")); err == nil {
+		opts.KeepAlive = v
+	}
+	if v, err := time.ParseDuration(query.Get("keepaliveperiod")); err == nil {
+		opts.KeepAlivePeriod = v
+	}
+	if v, err := strconv.Atoi(query.Get("keepalivecount")); err == nil {
+		opts.KeepAliveCount = v
+	}
+	if v, err := strconv.Atoi(query.Get("readbuffer")); err == nil {
+		opts.ReadBuffer = v
+	}
+	if v, err := strconv.Atoi(query.Get("writebuffer")); err == nil {
+		opts.WriteBuffer = v
+	}
+	if v, err := strconv.ParseBool(query.Get("nodelay")); err == nil {
+		opts.NoDelay = v
+	}
+	if v, err := time.ParseDuration(query.Get("usertimeout")); err == nil {
+		opts.UserTimeout = v
+	}
+
+	return opts
+}
+
+// underlyingTCPConn 尝试从给定连接中取出底层 *net.TCPConn，
+// 用于TLS等包装连接（tls.Conn.NetConn()）；QUIC流、WebSocket连接没有底层TCPConn，返回false
+func underlyingTCPConn(c net.Conn) (*net.TCPConn, bool) {
+	type netConnUnwrapper interface {
+		NetConn() net.Conn
+	}
+
+	for {
+		if tcpConn, ok := c.(*net.TCPConn); ok {
+			return tcpConn, true
+		}
+		unwrapper, ok := c.(netConnUnwrapper)
+		if !ok {
+			return nil, false
+		}
+		c = unwrapper.NetConn()
+	}
+}
+
+// applySocketOptions 将SocketOptions应用到一条已建立的TCP连接
+func applySocketOptions(tcpConn *net.TCPConn, opts SocketOptions) error {
+	if err := tcpConn.SetKeepAlive(opts.KeepAlive); err != nil {
+		return err
+	}
+	if opts.KeepAlive && opts.KeepAlivePeriod > 0 {
+		if err := tcpConn.SetKeepAlivePeriod(opts.KeepAlivePeriod); err != nil {
+			return err
+		}
+	}
+	if opts.ReadBuffer > 0 {
+		if err := tcpConn.SetReadBuffer(opts.ReadBuffer); err != nil {
+			return err
+		}
+	}
+	if opts.WriteBuffer > 0 {
+		if err := tcpConn.SetWriteBuffer(opts.WriteBuffer); err != nil {
+			return err
+		}
+	}
+	if err := tcpConn.SetNoDelay(opts.NoDelay); err != nil {
+		return err
+	}
+	return setPlatformSocketOptions(tcpConn, opts)
+}
@@ -0,0 +1,56 @@
+//go:build linux
+
+package internal
+
+import (
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// setListenSocketBuffers 在监听阶段（而非单条连接）设置SO_RCVBUF/SO_SNDBUF，
+// 使监听socket继承给后续accept出的连接
+func setListenSocketBuffers(c syscall.RawConn, opts SocketOptions) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		if opts.ReadBuffer > 0 {
+			sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_RCVBUF, opts.ReadBuffer)
+			if sockErr != nil {
+				return
+			}
+		}
+		if opts.WriteBuffer > 0 {
+			sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_SNDBUF, opts.WriteBuffer)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// setPlatformSocketOptions 在Linux上补齐标准库未暴露的TCP_USER_TIMEOUT/TCP_KEEPCNT
+func setPlatformSocketOptions(tcpConn *net.TCPConn, opts SocketOptions) error {
+	rawConn, err := tcpConn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var sockErr error
+	err = rawConn.Control(func(fd uintptr) {
+		if opts.UserTimeout > 0 {
+			sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_USER_TIMEOUT, int(opts.UserTimeout.Milliseconds()))
+			if sockErr != nil {
+				return
+			}
+		}
+		if opts.KeepAlive && opts.KeepAliveCount > 0 {
+			sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_KEEPCNT, opts.KeepAliveCount)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
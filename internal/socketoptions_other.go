@@ -0,0 +1,18 @@
+//go:build !linux
+
+package internal
+
+import (
+	"net"
+	"syscall"
+)
+
+// setPlatformSocketOptions 在非Linux平台上没有可移植的TCP_USER_TIMEOUT/TCP_KEEPCNT实现，空操作
+func setPlatformSocketOptions(tcpConn *net.TCPConn, opts SocketOptions) error {
+	return nil
+}
+
+// setListenSocketBuffers 在非Linux平台上没有统一的syscall接口，空操作
+func setListenSocketBuffers(c syscall.RawConn, opts SocketOptions) error {
+	return nil
+}
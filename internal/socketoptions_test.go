@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestParseSocketOptionsDefaults 空查询参数时应原样回退到 defaultSocketOptions
+func TestParseSocketOptionsDefaults(t *testing.T) {
+	got := parseSocketOptions(url.Values{})
+	if got != defaultSocketOptions {
+		t.Fatalf("parseSocketOptions(empty): want %+v, got %+v", defaultSocketOptions, got)
+	}
+}
+
+// TestParseSocketOptionsOverrides 可解析的查询参数应覆盖对应字段，其余字段保留默认值
+func TestParseSocketOptionsOverrides(t *testing.T) {
+	query := url.Values{
+		"keepalive":       {"false"},
+		"keepaliveperiod": {"10s"},
+		"keepalivecount":  {"5"},
+		"readbuffer":      {"65536"},
+		"writebuffer":     {"32768"},
+		"nodelay":         {"false"},
+		"usertimeout":     {"3s"},
+	}
+
+	got := parseSocketOptions(query)
+	want := SocketOptions{
+		KeepAlive:       false,
+		KeepAlivePeriod: 10 * time.Second,
+		KeepAliveCount:  5,
+		ReadBuffer:      65536,
+		WriteBuffer:     32768,
+		NoDelay:         false,
+		UserTimeout:     3 * time.Second,
+	}
+	if got != want {
+		t.Fatalf("parseSocketOptions(overrides): want %+v, got %+v", want, got)
+	}
+}
+
+// TestParseSocketOptionsInvalidValuesFallBackToDefaults 无法解析的查询值应保留默认值而不是清零字段
+func TestParseSocketOptionsInvalidValuesFallBackToDefaults(t *testing.T) {
+	query := url.Values{
+		"keepalive":       {"not-a-bool"},
+		"readbuffer":      {"not-a-number"},
+		"keepaliveperiod": {"not-a-duration"},
+	}
+
+	got := parseSocketOptions(query)
+	if got != defaultSocketOptions {
+		t.Fatalf("parseSocketOptions(invalid): want defaults %+v, got %+v", defaultSocketOptions, got)
+	}
+}
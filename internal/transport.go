@@ -0,0 +1,293 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/quic-go/quic-go"
+)
+
+// TunnelTransport 抽象隧道控制通道的底层承载方式，
+// 屏蔽 TCP/TLS/QUIC/WebSocket 之间的监听与接受差异
+type TunnelTransport interface {
+	// Listen 在 addr 上建立监听，返回的 net.Listener 可直接交给隧道连接池使用
+	Listen(ctx context.Context, addr string) (net.Listener, error)
+	// Name 返回承载方式名称，用于日志；握手帧中不携带该名称
+	Name() string
+	// TerminatesTLS 表示该承载方式是否已在Listen阶段自行完成TLS（或等效）握手，
+	// 为true时隧道连接池不应再对Accept出的连接做一次TLS握手，否则会造成双重握手
+	TerminatesTLS() bool
+}
+
+// selectTransport 依据URL scheme选择隧道承载方式，未知scheme时按是否配置TLS回退到tcp/tls
+func selectTransport(scheme string, tlsConfig *tls.Config, socketOptions SocketOptions) TunnelTransport {
+	switch scheme {
+	case "quic":
+		return &quicTransport{tlsConfig: tlsConfig}
+	case "ws":
+		return &wsTransport{}
+	case "wss":
+		return &wsTransport{tlsConfig: tlsConfig}
+	case "tls":
+		return &tlsTransport{tlsConfig: tlsConfig, socketOptions: socketOptions}
+	default:
+		if tlsConfig != nil {
+			return &tlsTransport{tlsConfig: tlsConfig, socketOptions: socketOptions}
+		}
+		return &tcpTransport{socketOptions: socketOptions}
+	}
+}
+
+// listenConfig 构建一个在监听阶段即应用SocketOptions（收发缓冲区）的net.ListenConfig
+func listenConfig(opts SocketOptions) net.ListenConfig {
+	return net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			return setListenSocketBuffers(c, opts)
+		},
+	}
+}
+
+// tcpTransport 裸TCP承载
+type tcpTransport struct {
+	socketOptions SocketOptions
+}
+
+func (t *tcpTransport) Name() string { return "tcp" }
+
+func (t *tcpTransport) TerminatesTLS() bool { return false }
+
+func (t *tcpTransport) Listen(ctx context.Context, addr string) (net.Listener, error) {
+	lc := listenConfig(t.socketOptions)
+	return lc.Listen(ctx, "tcp", addr)
+}
+
+// tlsTransport TLS承载，沿用现有 tlsConfig
+type tlsTransport struct {
+	tlsConfig     *tls.Config
+	socketOptions SocketOptions
+}
+
+func (t *tlsTransport) Name() string { return "tls" }
+
+// TerminatesTLS 为true：Listen已将监听器包装为tls.Listener，TLS握手在Accept时完成，
+// 隧道连接池不应再对取出的连接做一次TLS握手
+func (t *tlsTransport) TerminatesTLS() bool { return true }
+
+func (t *tlsTransport) Listen(ctx context.Context, addr string) (net.Listener, error) {
+	lc := listenConfig(t.socketOptions)
+	tcpListener, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return tls.NewListener(tcpListener, t.tlsConfig), nil
+}
+
+// quicTransport 基于 QUIC 的承载，每个被池化的连接对应一条独立的 QUIC 流，
+// 从而在共享的信令通道上避免队头阻塞，并在 NAT 重绑定后仍能存活
+type quicTransport struct {
+	tlsConfig *tls.Config
+}
+
+func (t *quicTransport) Name() string { return "quic" }
+
+// TerminatesTLS 为true：QUIC自身强制要求TLS1.3，握手在quic.ListenAddr阶段完成
+func (t *quicTransport) TerminatesTLS() bool { return true }
+
+func (t *quicTransport) Listen(ctx context.Context, addr string) (net.Listener, error) {
+	tlsConfig := t.tlsConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	listener, err := quic.ListenAddr(addr, tlsConfig, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &quicListener{ctx: ctx, listener: listener}, nil
+}
+
+// quicListener 将 quic.Listener 适配为 net.Listener，
+// 首个 Accept 接受底层 QUIC 连接，每次 Accept 都在其上接受一条对端新开的流
+type quicListener struct {
+	ctx      context.Context
+	listener *quic.Listener
+
+	conn quic.Connection
+}
+
+func (l *quicListener) Accept() (net.Conn, error) {
+	if l.conn == nil {
+		conn, err := l.listener.Accept(l.ctx)
+		if err != nil {
+			return nil, err
+		}
+		l.conn = conn
+	}
+
+	stream, err := l.conn.AcceptStream(l.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &quicStreamConn{conn: l.conn, stream: stream}, nil
+}
+
+func (l *quicListener) Close() error {
+	if l.conn != nil {
+		l.conn.CloseWithError(0, "")
+	}
+	return l.listener.Close()
+}
+
+func (l *quicListener) Addr() net.Addr {
+	return l.listener.Addr()
+}
+
+// quicStreamConn 将一条 QUIC 流包装为 net.Conn，复用底层连接的地址信息
+type quicStreamConn struct {
+	conn   quic.Connection
+	stream quic.Stream
+}
+
+func (c *quicStreamConn) Read(b []byte) (int, error)  { return c.stream.Read(b) }
+func (c *quicStreamConn) Write(b []byte) (int, error) { return c.stream.Write(b) }
+func (c *quicStreamConn) Close() error                { return c.stream.Close() }
+func (c *quicStreamConn) LocalAddr() net.Addr         { return c.conn.LocalAddr() }
+func (c *quicStreamConn) RemoteAddr() net.Addr        { return c.conn.RemoteAddr() }
+func (c *quicStreamConn) SetDeadline(t time.Time) error {
+	return c.stream.SetDeadline(t)
+}
+func (c *quicStreamConn) SetReadDeadline(t time.Time) error {
+	return c.stream.SetReadDeadline(t)
+}
+func (c *quicStreamConn) SetWriteDeadline(t time.Time) error {
+	return c.stream.SetWriteDeadline(t)
+}
+
+// wsTransport 基于 WebSocket 的承载，使隧道能够经过七层代理/CDN（如 nginx、Cloudflare）
+type wsTransport struct {
+	tlsConfig *tls.Config
+}
+
+func (t *wsTransport) Name() string { return "ws" }
+
+// TerminatesTLS 为true：取出的连接承载的是WebSocket消息帧而非原始字节流
+// （TLS若启用也已在Listen阶段的tls.NewListener中完成），连接池不能再对其做原始TLS握手
+func (t *wsTransport) TerminatesTLS() bool { return true }
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  udpDataBufSize,
+	WriteBufferSize: udpDataBufSize,
+}
+
+func (t *wsTransport) Listen(ctx context.Context, addr string) (net.Listener, error) {
+	var lc net.ListenConfig
+	tcpListener, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if t.tlsConfig != nil {
+		tcpListener = tls.NewListener(tcpListener, t.tlsConfig)
+	}
+
+	wl := &wsListener{
+		tcpListener: tcpListener,
+		accepted:    make(chan net.Conn),
+		closed:      make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", wl.handleUpgrade)
+	wl.server = &http.Server{Handler: mux}
+
+	go wl.server.Serve(tcpListener)
+
+	return wl, nil
+}
+
+// wsListener 把 WebSocket 升级握手适配为 net.Listener，
+// 每个成功升级的连接都通过 accepted 通道交给隧道连接池
+type wsListener struct {
+	tcpListener net.Listener
+	server      *http.Server
+	accepted    chan net.Conn
+	closed      chan struct{}
+}
+
+func (l *wsListener) handleUpgrade(w http.ResponseWriter, r *http.Request) {
+	wsConn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	select {
+	case l.accepted <- &wsNetConn{wsConn: wsConn}:
+	case <-l.closed:
+		wsConn.Close()
+	}
+}
+
+func (l *wsListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.accepted:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *wsListener) Close() error {
+	select {
+	case <-l.closed:
+	default:
+		close(l.closed)
+	}
+	return l.tcpListener.Close()
+}
+
+func (l *wsListener) Addr() net.Addr {
+	return l.tcpListener.Addr()
+}
+
+// wsNetConn 将 *websocket.Conn 的消息语义适配为 net.Conn 的流式 Read/Write
+type wsNetConn struct {
+	wsConn *websocket.Conn
+	reader io.Reader
+}
+
+func (c *wsNetConn) Read(b []byte) (int, error) {
+	for c.reader == nil {
+		_, reader, err := c.wsConn.NextReader()
+		if err != nil {
+			return 0, err
+		}
+		c.reader = reader
+	}
+
+	n, err := c.reader.Read(b)
+	if err == io.EOF {
+		c.reader = nil
+		if n > 0 {
+			return n, nil
+		}
+		return 0, nil
+	}
+	return n, err
+}
+
+func (c *wsNetConn) Write(b []byte) (int, error) {
+	if err := c.wsConn.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *wsNetConn) Close() error                       { return c.wsConn.Close() }
+func (c *wsNetConn) LocalAddr() net.Addr                { return c.wsConn.LocalAddr() }
+func (c *wsNetConn) RemoteAddr() net.Addr               { return c.wsConn.RemoteAddr() }
+func (c *wsNetConn) SetDeadline(t time.Time) error      { return c.wsConn.UnderlyingConn().SetDeadline(t) }
+func (c *wsNetConn) SetReadDeadline(t time.Time) error  { return c.wsConn.SetReadDeadline(t) }
+func (c *wsNetConn) SetWriteDeadline(t time.Time) error { return c.wsConn.SetWriteDeadline(t) }
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestUDPSession 构造一个仅用于练习trySend/closeSend的最小udpSession，不涉及真实网络连接
+func newTestUDPSession() *udpSession {
+	sess := &udpSession{send: make(chan []byte, udpSessionSendBuf)}
+	sess.touch()
+	return sess
+}
+
+// TestUDPSessionTrySendAfterCloseSendDoesNotPanic 是回归测试：
+// closeSend与trySend并发执行时，trySend必须能观察到closed而不对已关闭的channel发送，
+// 否则会panic("send on closed channel")
+func TestUDPSessionTrySendAfterCloseSendDoesNotPanic(t *testing.T) {
+	sess := newTestUDPSession()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			sess.trySend([]byte("payload"))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		sess.closeSend()
+	}()
+
+	wg.Wait()
+
+	if sent := sess.trySend([]byte("after-close")); sent {
+		t.Fatal("trySend after closeSend: want false, got true")
+	}
+}
+
+// TestUDPSessionCloseSendIsIdempotent 多个owner并发调用closeSend时只应实际关闭一次channel
+func TestUDPSessionCloseSendIsIdempotent(t *testing.T) {
+	sess := newTestUDPSession()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sess.closeSend()
+		}()
+	}
+	wg.Wait()
+
+	select {
+	case _, ok := <-sess.send:
+		if ok {
+			t.Fatal("send channel should be empty and closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("send channel was not closed")
+	}
+}